@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 )
 
 type (
@@ -182,16 +183,54 @@ func CustomUnit(unit string) MetricUnit {
 	}
 }
 
+// Metric is the write side of a metric instance: the identity of the series
+// plus the ability to record new samples. Add races with a concurrent flush
+// unless callers go through Snapshot, which is why the read-only view lives
+// on MetricSnapshot instead of here.
 type Metric interface {
+	GetTags() map[string]string
+	GetKey() string
+	GetUnit() string
+	GetCreatedAt() int64
+	Add(value interface{})
+	// Snapshot takes a point-in-time, concurrency-safe copy of the metric's
+	// current state. The returned MetricSnapshot is immutable and can be
+	// serialized by a flusher without any further locking.
+	Snapshot() MetricSnapshot
+}
+
+// MetricSnapshot is the read side of a metric instance, as captured by
+// Metric.Snapshot at a single point in time.
+type MetricSnapshot interface {
 	GetType() string
 	GetTags() map[string]string
 	GetKey() string
 	GetUnit() string
 	GetTimestamp() int64
+	GetCreatedAt() int64
 	GetWeight() int
 	SerializeValue() string
 	SerializeTags() string
-	Add(value interface{})
+}
+
+// HistogramSnapshot is implemented by the MetricSnapshot returned for a
+// HistogramMetric. Sinks that only see a []MetricSnapshot (e.g. a
+// MetricSink) can type-assert to this interface to recover the fixed bucket
+// boundaries needed to emit a proper OTLP/Prometheus histogram, since
+// MetricSnapshot itself carries no type-specific state.
+type HistogramSnapshot interface {
+	MetricSnapshot
+	// Buckets returns the configured bucket upper bounds, not including the
+	// implicit trailing +Inf bucket.
+	Buckets() []float64
+}
+
+// MetricSink receives a flush's worth of metric snapshots and ships them
+// somewhere. Implementations must not retain the passed-in slice or its
+// elements past the call, since the caller reuses its backing buffer across
+// flushes.
+type MetricSink interface {
+	Flush(snapshots []MetricSnapshot) error
 }
 
 type abstractMetric struct {
@@ -200,6 +239,12 @@ type abstractMetric struct {
 	tags map[string]string
 	// A unix timestamp (full seconds elapsed since 1970-01-01 00:00 UTC).
 	timestamp int64
+	// A unix timestamp marking when this metric instance was constructed,
+	// as opposed to when the last sample was recorded. Two metrics that are
+	// otherwise identical but were created at different times describe
+	// different lifecycles (e.g. a counter that reset after a restart) and
+	// must not be merged by the aggregator.
+	createdAt int64
 }
 
 func (am *abstractMetric) GetTags() map[string]string {
@@ -218,92 +263,158 @@ func (am *abstractMetric) GetTimestamp() int64 {
 	return am.timestamp
 }
 
+// GetCreatedAt returns when this metric instance was constructed, so callers
+// can tell a counter that was just re-created apart from one that genuinely
+// reset to zero, and compute accurate rates across process restarts. Keying
+// the aggregator by it (so two otherwise-identical metrics with different
+// createdAt are never merged) is the responsibility of the aggregator code,
+// which lives outside this file.
+func (am *abstractMetric) GetCreatedAt() int64 {
+	return am.createdAt
+}
+
 func (am *abstractMetric) SerializeTags() string {
 	return serializeTags(am.tags)
 }
 
+// serializeCreatedAt renders the "|T<created>" suffix appended to
+// SerializeValue output, parallel to the existing timestamp marker, so
+// downstream consumers can tell a counter that was just re-created apart
+// from one that genuinely reset to zero.
+func (am *abstractMetric) serializeCreatedAt() string {
+	return fmt.Sprintf("|T%d", am.createdAt)
+}
+
+// newAbstractMetric fills in createdAt from created, falling back to the
+// sample timestamp when created is zero.
+func newAbstractMetric(key string, unit MetricUnit, tags map[string]string, timestamp int64, created int64) abstractMetric {
+	if created == 0 {
+		created = timestamp
+	}
+	return abstractMetric{
+		key,
+		unit,
+		tags,
+		timestamp,
+		created,
+	}
+}
+
 // Counter Metric.
 type CounterMetric struct {
+	mu    sync.Mutex
 	value float64
 	abstractMetric
 }
 
 func (c *CounterMetric) Add(value interface{}) {
 	v := value.(float64)
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.value += v
 }
 
-func (c *CounterMetric) GetType() string {
-	return "c"
+func (c *CounterMetric) Snapshot() MetricSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &counterSnapshot{
+		value:          c.value,
+		abstractMetric: c.abstractMetric,
+	}
 }
 
-func (c *CounterMetric) GetWeight() int {
-	return 1
+// timestamp: A unix timestamp (full seconds elapsed since 1970-01-01 00:00 UTC).
+//
+// created: A unix timestamp marking when this counter was (re)created; pass 0
+// to default to timestamp.
+func NewCounterMetric(key string, unit MetricUnit, tags map[string]string, timestamp int64, created int64, value float64) *CounterMetric {
+	return &CounterMetric{
+		value:          value,
+		abstractMetric: newAbstractMetric(key, unit, tags, timestamp, created),
+	}
 }
 
-func (c *CounterMetric) SerializeValue() string {
-	return fmt.Sprintf(":%v", c.value)
+type counterSnapshot struct {
+	value float64
+	abstractMetric
 }
 
-// timestamp: A unix timestamp (full seconds elapsed since 1970-01-01 00:00 UTC).
-func NewCounterMetric(key string, unit MetricUnit, tags map[string]string, timestamp int64, value float64) *CounterMetric {
-	am := abstractMetric{
-		key,
-		unit,
-		tags,
-		timestamp,
-	}
+func (c *counterSnapshot) GetType() string {
+	return "c"
+}
 
-	return &CounterMetric{
-		value,
-		am,
-	}
+func (c *counterSnapshot) GetWeight() int {
+	return 1
+}
+
+func (c *counterSnapshot) SerializeValue() string {
+	return fmt.Sprintf(":%v%s", c.value, c.serializeCreatedAt())
 }
 
 // Distribution Metric.
 type DistributionMetric struct {
+	mu     sync.Mutex
 	values []float64
 	abstractMetric
 }
 
 func (d *DistributionMetric) Add(value interface{}) {
 	v := value.(float64)
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.values = append(d.values, v)
 }
 
-func (d *DistributionMetric) GetType() string {
+// Snapshot swaps out the live sample buffer for a fresh one and hands the old
+// buffer to the returned snapshot, so a concurrent Add never has to wait on a
+// flush in progress.
+func (d *DistributionMetric) Snapshot() MetricSnapshot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	values := d.values
+	d.values = make([]float64, 0)
+	return &distributionSnapshot{
+		values:         values,
+		abstractMetric: d.abstractMetric,
+	}
+}
+
+// timestamp: A unix timestamp (full seconds elapsed since 1970-01-01 00:00 UTC).
+//
+// created: A unix timestamp marking when this distribution was (re)created;
+// pass 0 to default to timestamp.
+func NewDistributionMetric(key string, unit MetricUnit, tags map[string]string, timestamp int64, created int64, value float64) *DistributionMetric {
+	return &DistributionMetric{
+		values:         []float64{value},
+		abstractMetric: newAbstractMetric(key, unit, tags, timestamp, created),
+	}
+}
+
+type distributionSnapshot struct {
+	values []float64
+	abstractMetric
+}
+
+func (d *distributionSnapshot) GetType() string {
 	return "d"
 }
 
-func (d *DistributionMetric) GetWeight() int {
+func (d *distributionSnapshot) GetWeight() int {
 	return len(d.values)
 }
 
-func (d *DistributionMetric) SerializeValue() string {
+func (d *distributionSnapshot) SerializeValue() string {
 	var sb strings.Builder
 	for _, el := range d.values {
 		sb.WriteString(fmt.Sprintf(":%v", el))
 	}
+	sb.WriteString(d.serializeCreatedAt())
 	return sb.String()
 }
 
-// timestamp: A unix timestamp (full seconds elapsed since 1970-01-01 00:00 UTC).
-func NewDistributionMetric(key string, unit MetricUnit, tags map[string]string, timestamp int64, value float64) *DistributionMetric {
-	am := abstractMetric{
-		key,
-		unit,
-		tags,
-		timestamp,
-	}
-
-	return &DistributionMetric{
-		[]float64{value},
-		am,
-	}
-}
-
 // Gauge Metric.
 type GaugeMetric struct {
+	mu    sync.Mutex
 	last  float64
 	min   float64
 	max   float64
@@ -314,6 +425,8 @@ type GaugeMetric struct {
 
 func (g *GaugeMetric) Add(value interface{}) {
 	v := value.(float64)
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	g.last = v
 	g.min = math.Min(g.min, v)
 	g.max = math.Max(g.max, v)
@@ -321,57 +434,114 @@ func (g *GaugeMetric) Add(value interface{}) {
 	g.count++
 }
 
-func (g *GaugeMetric) GetType() string {
-	return "g"
+func (g *GaugeMetric) Snapshot() MetricSnapshot {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return &gaugeSnapshot{
+		last:           g.last,
+		min:            g.min,
+		max:            g.max,
+		sum:            g.sum,
+		count:          g.count,
+		abstractMetric: g.abstractMetric,
+	}
 }
 
-func (g *GaugeMetric) GetWeight() int {
-	return 5
+// timestamp: A unix timestamp (full seconds elapsed since 1970-01-01 00:00 UTC).
+//
+// created: A unix timestamp marking when this gauge was (re)created; pass 0
+// to default to timestamp.
+func NewGaugeMetric(key string, unit MetricUnit, tags map[string]string, timestamp int64, created int64, value float64) *GaugeMetric {
+	return &GaugeMetric{
+		last:           value,
+		min:            value,
+		max:            value,
+		sum:            value,
+		count:          value,
+		abstractMetric: newAbstractMetric(key, unit, tags, timestamp, created),
+	}
 }
 
-func (g *GaugeMetric) SerializeValue() string {
-	return fmt.Sprintf(":%v:%v:%v:%v:%v", g.last, g.min, g.max, g.sum, g.count)
+type gaugeSnapshot struct {
+	last  float64
+	min   float64
+	max   float64
+	sum   float64
+	count float64
+	abstractMetric
 }
 
-// timestamp: A unix timestamp (full seconds elapsed since 1970-01-01 00:00 UTC).
-func NewGaugeMetric(key string, unit MetricUnit, tags map[string]string, timestamp int64, value float64) *GaugeMetric {
-	am := abstractMetric{
-		key,
-		unit,
-		tags,
-		timestamp,
-	}
+func (g *gaugeSnapshot) GetType() string {
+	return "g"
+}
 
-	return &GaugeMetric{
-		value, // last
-		value, // min
-		value, // max
-		value, // sum
-		value, // count
-		am,
-	}
+func (g *gaugeSnapshot) GetWeight() int {
+	return 5
+}
+
+func (g *gaugeSnapshot) SerializeValue() string {
+	return fmt.Sprintf(":%v:%v:%v:%v:%v%s", g.last, g.min, g.max, g.sum, g.count, g.serializeCreatedAt())
 }
 
 // Set Metric.
 type SetMetric struct {
+	mu     sync.Mutex
 	values map[int]void
 	abstractMetric
 }
 
 func (s *SetMetric) Add(value interface{}) {
 	v := value.(int)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.values[v] = member
 }
 
-func (s *SetMetric) GetType() string {
+// Snapshot swaps out the live membership map for a fresh one and hands the
+// old map to the returned snapshot, so a concurrent Add never has to wait on
+// a flush in progress.
+func (s *SetMetric) Snapshot() MetricSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := s.values
+	s.values = make(map[int]void)
+	return &setSnapshot{
+		values:         values,
+		abstractMetric: s.abstractMetric,
+	}
+}
+
+func setStringKeyToInt(s string) uint32 {
+	return crc32.ChecksumIEEE([]byte(s))
+}
+
+// timestamp: A unix timestamp (full seconds elapsed since 1970-01-01 00:00 UTC).
+//
+// created: A unix timestamp marking when this set was (re)created; pass 0 to
+// default to timestamp.
+func NewSetMetric[T NumberOrString](key string, unit MetricUnit, tags map[string]string, timestamp int64, created int64, value int) *SetMetric {
+	return &SetMetric{
+		values: map[int]void{
+			value: member,
+		},
+		abstractMetric: newAbstractMetric(key, unit, tags, timestamp, created),
+	}
+}
+
+type setSnapshot struct {
+	values map[int]void
+	abstractMetric
+}
+
+func (s *setSnapshot) GetType() string {
 	return "s"
 }
 
-func (s *SetMetric) GetWeight() int {
+func (s *setSnapshot) GetWeight() int {
 	return len(s.values)
 }
 
-func (s *SetMetric) SerializeValue() string {
+func (s *setSnapshot) SerializeValue() string {
 	values := make([]int, 0, len(s.values))
 	for k := range s.values {
 		values = append(values, k)
@@ -382,29 +552,167 @@ func (s *SetMetric) SerializeValue() string {
 	for _, el := range values {
 		sb.WriteString(fmt.Sprintf(":%v", el))
 	}
+	sb.WriteString(s.serializeCreatedAt())
 
 	return sb.String()
 }
 
-func setStringKeyToInt(s string) uint32 {
-	return crc32.ChecksumIEEE([]byte(s))
+// Histogram Metric.
+//
+// Unlike DistributionMetric, which keeps every raw sample, HistogramMetric
+// keeps bounded-memory state: values are sorted into buckets up front and
+// only the per-bucket counts (plus running sum/count/min/max) are retained.
+type HistogramMetric struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+	min     float64
+	max     float64
+	abstractMetric
+}
+
+func (h *HistogramMetric) Add(value interface{}) {
+	v := value.(float64)
+	idx := sort.Search(len(h.buckets), func(i int) bool {
+		return v <= h.buckets[i]
+	})
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[idx]++
+	h.sum += v
+	h.count++
+	h.min = math.Min(h.min, v)
+	h.max = math.Max(h.max, v)
+}
+
+// Snapshot copies the current bucket counts and running totals. Unlike
+// DistributionMetric and SetMetric, the bucket layout is fixed-size, so there
+// is no benefit to swapping out the live buffer: a copy is just as cheap.
+func (h *HistogramMetric) Snapshot() MetricSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	counts := make([]uint64, len(h.counts))
+	copy(counts, h.counts)
+	return &histogramSnapshot{
+		buckets:        h.buckets,
+		counts:         counts,
+		sum:            h.sum,
+		count:          h.count,
+		min:            h.min,
+		max:            h.max,
+		abstractMetric: h.abstractMetric,
+	}
+}
+
+// Buckets returns the configured bucket upper bounds, not including the
+// implicit trailing +Inf bucket.
+func (h *HistogramMetric) Buckets() []float64 {
+	buckets := make([]float64, len(h.buckets))
+	copy(buckets, h.buckets)
+	return buckets
 }
 
 // timestamp: A unix timestamp (full seconds elapsed since 1970-01-01 00:00 UTC).
-func NewSetMetric[T NumberOrString](key string, unit MetricUnit, tags map[string]string, timestamp int64, value int) *SetMetric {
-	am := abstractMetric{
-		key,
-		unit,
-		tags,
-		timestamp,
-	}
+//
+// buckets must be strictly increasing bucket upper bounds; NewHistogramMetric
+// panics otherwise. An implicit +Inf bucket is appended to catch values above
+// the last boundary.
+func NewHistogramMetric(key string, unit MetricUnit, tags map[string]string, timestamp int64, buckets []float64, value float64) *HistogramMetric {
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			panic("sentry: histogram buckets must be strictly increasing")
+		}
+	}
+
+	h := &HistogramMetric{
+		buckets:        buckets,
+		counts:         make([]uint64, len(buckets)+1),
+		min:            math.Inf(1),
+		max:            math.Inf(-1),
+		abstractMetric: newAbstractMetric(key, unit, tags, timestamp, 0),
+	}
+	h.Add(value)
+
+	return h
+}
+
+type histogramSnapshot struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+	min     float64
+	max     float64
+	abstractMetric
+}
 
-	return &SetMetric{
-		map[int]void{
-			value: member,
-		},
-		am,
+func (h *histogramSnapshot) GetType() string {
+	return "h"
+}
+
+func (h *histogramSnapshot) GetWeight() int {
+	return len(h.buckets) + 3
+}
+
+func (h *histogramSnapshot) SerializeValue() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(":%v:%v:%v:%v", h.sum, h.count, h.min, h.max))
+	for _, c := range h.counts {
+		sb.WriteString(fmt.Sprintf(":%v", c))
 	}
+	sb.WriteString(h.serializeCreatedAt())
+	return sb.String()
+}
+
+// Buckets returns the configured bucket upper bounds, not including the
+// implicit trailing +Inf bucket. It satisfies HistogramSnapshot.
+func (h *histogramSnapshot) Buckets() []float64 {
+	buckets := make([]float64, len(h.buckets))
+	copy(buckets, h.buckets)
+	return buckets
+}
+
+// Quantile returns an estimate of the q-th quantile (0 <= q <= 1) of the
+// observed values, linearly interpolating within the bucket that the target
+// rank falls into. It has no access to the individual samples, so the result
+// is an approximation bounded by the bucket width.
+func (h *histogramSnapshot) Quantile(q float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+
+	target := q * float64(h.count)
+
+	var cumulative uint64
+	// The first bucket has no real lower edge (it catches everything up to
+	// its upper bound), so seed with the observed minimum rather than 0:
+	// buckets are only required to be strictly increasing, not non-negative,
+	// and a hardcoded 0 would sit inside the domain for all-negative data.
+	lower := h.min
+	for i, c := range h.counts {
+		prevCumulative := cumulative
+		cumulative += c
+
+		if float64(cumulative) >= target {
+			upper := math.Inf(1)
+			if i < len(h.buckets) {
+				upper = h.buckets[i]
+			}
+			if c == 0 || math.IsInf(upper, 1) {
+				return lower
+			}
+			frac := (target - float64(prevCumulative)) / float64(c)
+			return lower + frac*(upper-lower)
+		}
+
+		if i < len(h.buckets) {
+			lower = h.buckets[i]
+		}
+	}
+
+	return h.max
 }
 
 func sanitizeKey(s string) string {
@@ -0,0 +1,175 @@
+package sentry
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewHistogramMetric_PanicsOnNonIncreasingBuckets(t *testing.T) {
+	tests := [][]float64{
+		{1, 1},
+		{5, 1, 10},
+		{1, 2, 2, 3},
+	}
+
+	for _, buckets := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("buckets %v: expected NewHistogramMetric to panic", buckets)
+				}
+			}()
+			NewHistogramMetric("k", Second(), nil, 0, buckets, 0)
+		}()
+	}
+}
+
+func TestHistogramMetric_AddBucketsValueIntoLowestMatchingBound(t *testing.T) {
+	h := NewHistogramMetric("k", Second(), nil, 0, []float64{1, 5, 10}, 1)
+	h.Add(5.0)  // exactly on the second boundary
+	h.Add(7.0)  // between second and third boundary
+	h.Add(20.0) // above every boundary, falls into the implicit +Inf bucket
+
+	snap := h.Snapshot().(*histogramSnapshot)
+	// counts: [<=1, <=5, <=10, +Inf] = [1 (from construction), 1, 1, 1]
+	want := []uint64{1, 1, 1, 1}
+	for i, c := range want {
+		if snap.counts[i] != c {
+			t.Errorf("counts[%d] = %d, want %d", i, snap.counts[i], c)
+		}
+	}
+	if snap.count != 4 {
+		t.Errorf("count = %d, want 4", snap.count)
+	}
+	if snap.min != 1 || snap.max != 20 {
+		t.Errorf("min/max = %v/%v, want 1/20", snap.min, snap.max)
+	}
+}
+
+func TestHistogramSnapshot_Quantile(t *testing.T) {
+	h := NewHistogramMetric("k", Second(), nil, 0, []float64{10, 20, 30}, 5)
+	for _, v := range []float64{5, 15, 15, 25} {
+		h.Add(v)
+	}
+
+	snap := h.Snapshot().(*histogramSnapshot)
+
+	if got := snap.Quantile(0); got < 0 || got > 10 {
+		t.Errorf("Quantile(0) = %v, want a value near the low end of the domain", got)
+	}
+}
+
+// TestHistogramSnapshot_QuantileWithNegativeBuckets guards against the
+// bucket-0 interpolation bug: the lower edge used to be hardcoded to 0,
+// which put estimates for negative-only data on the wrong side of the
+// bucket boundary they were supposed to fall under.
+func TestHistogramSnapshot_QuantileWithNegativeBuckets(t *testing.T) {
+	h := NewHistogramMetric("k", Second(), nil, 0, []float64{-50, 0, 50}, -80)
+	h.Add(-80.0)
+
+	snap := h.Snapshot().(*histogramSnapshot)
+
+	got := snap.Quantile(0.5)
+	if got > -50 {
+		t.Errorf("Quantile(0.5) = %v, want <= -50 (the bucket-0 upper bound); "+
+			"a hardcoded 0 lower edge would place this above it", got)
+	}
+}
+
+func TestHistogramSnapshot_SerializeValue(t *testing.T) {
+	h := NewHistogramMetric("k", Second(), nil, 0, []float64{1, 2}, 1)
+	serialized := h.Snapshot().SerializeValue()
+
+	// :sum:count:min:max:b0:b1:b2|T<created>
+	if !strings.HasPrefix(serialized, ":1:1:1:1:") {
+		t.Errorf("SerializeValue() = %q, want a :sum:count:min:max: prefix", serialized)
+	}
+	if !strings.Contains(serialized, "|T") {
+		t.Errorf("SerializeValue() = %q, want a |T<created> suffix", serialized)
+	}
+}
+
+func TestHistogramMetric_Buckets_ReturnsCopy(t *testing.T) {
+	h := NewHistogramMetric("k", Second(), nil, 0, []float64{1, 2, 3}, 1)
+	buckets := h.Buckets()
+	buckets[0] = 999
+
+	if h.buckets[0] == 999 {
+		t.Errorf("Buckets() leaked a mutable reference to internal state")
+	}
+}
+
+// TestCounterMetric_ConcurrentAddAndSnapshot exercises Add and Snapshot from
+// many goroutines at once. Run with -race to confirm the mutex actually
+// guards the shared state; run plain to confirm no increments are lost.
+func TestCounterMetric_ConcurrentAddAndSnapshot(t *testing.T) {
+	c := NewCounterMetric("k", Second(), nil, 0, 0, 0)
+
+	const goroutines = 50
+	const perGoroutine = 100
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				c.Add(1.0)
+			}
+		}()
+	}
+
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		for i := 0; i < goroutines*perGoroutine; i++ {
+			c.Snapshot()
+		}
+	}()
+
+	wg.Wait()
+	<-readerDone
+
+	final := c.Snapshot().(*counterSnapshot)
+	want := float64(goroutines * perGoroutine)
+	if final.value != want {
+		t.Errorf("final counter value = %v, want %v (lost increments under concurrent Add)", final.value, want)
+	}
+}
+
+func TestDistributionMetric_SnapshotSwapsBuffer(t *testing.T) {
+	d := NewDistributionMetric("k", Second(), nil, 0, 0, 1)
+	d.Add(2.0)
+
+	first := d.Snapshot().(*distributionSnapshot)
+	if len(first.values) != 2 {
+		t.Fatalf("first snapshot has %d values, want 2", len(first.values))
+	}
+
+	d.Add(3.0)
+	second := d.Snapshot().(*distributionSnapshot)
+	if len(second.values) != 1 {
+		t.Fatalf("second snapshot has %d values, want 1 (the swap should have reset the buffer)", len(second.values))
+	}
+	if len(first.values) != 2 {
+		t.Errorf("taking a second snapshot mutated the first snapshot's values")
+	}
+}
+
+func TestAbstractMetric_SerializeCreatedAt(t *testing.T) {
+	c := NewCounterMetric("k", Second(), nil, 100, 42, 1)
+	serialized := c.Snapshot().SerializeValue()
+	want := fmt.Sprintf("|T%d", 42)
+	if !strings.HasSuffix(serialized, want) {
+		t.Errorf("SerializeValue() = %q, want suffix %q", serialized, want)
+	}
+}
+
+func TestNewCounterMetric_DefaultsCreatedAtToTimestamp(t *testing.T) {
+	c := NewCounterMetric("k", Second(), nil, 100, 0, 1)
+	if c.GetCreatedAt() != 100 {
+		t.Errorf("GetCreatedAt() = %d, want 100 (fallback to timestamp)", c.GetCreatedAt())
+	}
+}
@@ -0,0 +1,237 @@
+// Package metricsprom renders the metrics held by an in-process aggregator
+// in the Prometheus text exposition format (version 0.0.4), so they can be
+// scraped directly without running a separate instrumentation library.
+package metricsprom
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	sentry "github.com/getsentry/sentry-go"
+)
+
+// promKeyRegex and promLabelRegex sanitize against Prometheus' own naming
+// rules, which are stricter than sentry's statsd-style keyRegex.
+var (
+	promKeyRegex   = regexp.MustCompile(`[^a-zA-Z0-9_:]+`)
+	promLabelRegex = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+)
+
+// Source supplies the metric instances currently held by an in-process
+// aggregator. Implement it against whatever registry owns your
+// sentry.Metric instances.
+type Source interface {
+	Metrics() []sentry.Metric
+}
+
+// Handler returns an http.Handler that renders the metrics exposed by src in
+// the Prometheus text exposition format.
+func Handler(src Source) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		fmt.Fprint(w, render(src.Metrics()))
+	})
+}
+
+// series is a point-in-time snapshot reduced from a metric's identity plus
+// its recorded values; rendering never needs to reach back into the live,
+// mutable Metric it came from.
+type series struct {
+	snap sentry.MetricSnapshot
+}
+
+func render(metrics []sentry.Metric) string {
+	all := make([]series, len(metrics))
+	for i, m := range metrics {
+		all[i] = series{snap: m.Snapshot()}
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].snap.GetKey() != all[j].snap.GetKey() {
+			return all[i].snap.GetKey() < all[j].snap.GetKey()
+		}
+		return all[i].snap.SerializeTags() < all[j].snap.SerializeTags()
+	})
+
+	var sb strings.Builder
+	seen := make(map[string]bool)
+
+	for _, s := range all {
+		key := sanitizeKey(s.snap.GetKey())
+
+		if !seen[key] {
+			seen[key] = true
+			if unit := s.snap.GetUnit(); unit != "" {
+				fmt.Fprintf(&sb, "# HELP %s Sentry metric, unit: %s.\n", key, unit)
+			}
+			fmt.Fprintf(&sb, "# TYPE %s %s\n", key, promType(s.snap.GetType()))
+		}
+
+		writeSeries(&sb, key, s)
+	}
+
+	return sb.String()
+}
+
+func promType(metricType string) string {
+	switch metricType {
+	case "c":
+		return "counter"
+	case "g", "s":
+		return "gauge"
+	case "d":
+		return "summary"
+	case "h":
+		return "histogram"
+	default:
+		return "untyped"
+	}
+}
+
+func writeSeries(sb *strings.Builder, key string, s series) {
+	labels := promLabels(s.snap.GetTags())
+	values := parseFloats(s.snap.SerializeValue())
+
+	switch s.snap.GetType() {
+	case "c":
+		writeSample(sb, key, labels, values[0])
+
+	case "s":
+		writeSample(sb, key, labels, float64(len(values)))
+
+	case "g":
+		last, min, max, sum, count := values[0], values[1], values[2], values[3], values[4]
+		writeSample(sb, key, labels, last)
+		writeSample(sb, key+"_min", labels, min)
+		writeSample(sb, key+"_max", labels, max)
+		writeSample(sb, key+"_sum", labels, sum)
+		writeSample(sb, key+"_count", labels, count)
+
+	case "d":
+		writeQuantiles(sb, key, labels, values)
+
+	case "h":
+		hist, ok := s.snap.(sentry.HistogramSnapshot)
+		if !ok {
+			return
+		}
+		writeHistogram(sb, key, labels, hist, values)
+	}
+}
+
+func writeQuantiles(sb *strings.Builder, key string, labels string, values []float64) {
+	sort.Float64s(values)
+
+	var sum, count float64
+	for _, v := range values {
+		sum += v
+		count++
+	}
+
+	for _, q := range []float64{0.5, 0.9, 0.95, 0.99, 1} {
+		writeSample(sb, key, joinLabels(labels, fmt.Sprintf(`quantile="%v"`, q)), quantileOf(values, q))
+	}
+	writeSample(sb, key+"_sum", labels, sum)
+	writeSample(sb, key+"_count", labels, count)
+}
+
+func quantileOf(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(q * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+func writeHistogram(sb *strings.Builder, key string, labels string, h sentry.HistogramSnapshot, values []float64) {
+	sum, count := values[0], values[1]
+	bucketCounts := values[4:]
+	buckets := h.Buckets()
+
+	var cumulative float64
+	for i, c := range bucketCounts {
+		cumulative += c
+		le := "+Inf"
+		if i < len(buckets) {
+			le = strconv.FormatFloat(buckets[i], 'g', -1, 64)
+		}
+		writeSample(sb, key+"_bucket", joinLabels(labels, fmt.Sprintf(`le="%s"`, le)), cumulative)
+	}
+	writeSample(sb, key+"_sum", labels, sum)
+	writeSample(sb, key+"_count", labels, count)
+}
+
+func writeSample(sb *strings.Builder, key string, labels string, value float64) {
+	if labels == "" {
+		fmt.Fprintf(sb, "%s %v\n", key, value)
+		return
+	}
+	fmt.Fprintf(sb, "%s{%s} %v\n", key, labels, value)
+}
+
+func joinLabels(labels string, extra string) string {
+	if labels == "" {
+		return extra
+	}
+	return labels + "," + extra
+}
+
+func promLabels(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, sanitizeLabel(k), escapeLabelValue(tags[k])))
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseFloats(serialized string) []float64 {
+	// Strip the "|T<created>" suffix (see abstractMetric.serializeCreatedAt)
+	// before splitting on ":", otherwise the last numeric field is swallowed
+	// along with it.
+	if i := strings.IndexByte(serialized, '|'); i >= 0 {
+		serialized = serialized[:i]
+	}
+	parts := strings.Split(strings.TrimPrefix(serialized, ":"), ":")
+	values := make([]float64, 0, len(parts))
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			continue
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+func sanitizeKey(s string) string {
+	s = promKeyRegex.ReplaceAllString(s, "_")
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "_" + s
+	}
+	return s
+}
+
+func sanitizeLabel(s string) string {
+	s = promLabelRegex.ReplaceAllString(s, "_")
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "_" + s
+	}
+	return s
+}
+
+func escapeLabelValue(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return s
+}
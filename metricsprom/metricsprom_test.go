@@ -0,0 +1,105 @@
+package metricsprom
+
+import (
+	"strings"
+	"testing"
+
+	sentry "github.com/getsentry/sentry-go"
+)
+
+func TestSanitizeKey(t *testing.T) {
+	tests := map[string]string{
+		"requests.total": "requests_total",
+		"my-metric":      "my_metric",
+		"9lives":         "_9lives",
+		"already_ok":     "already_ok",
+	}
+	for in, want := range tests {
+		if got := sanitizeKey(in); got != want {
+			t.Errorf("sanitizeKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSanitizeLabel(t *testing.T) {
+	tests := map[string]string{
+		"http.status": "http_status",
+		"2xx":         "_2xx",
+		"region":      "region",
+	}
+	for in, want := range tests {
+		if got := sanitizeLabel(in); got != want {
+			t.Errorf("sanitizeLabel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	in := "line\\one\nwith \"quotes\""
+	want := `line\\one\nwith \"quotes\"`
+	if got := escapeLabelValue(in); got != want {
+		t.Errorf("escapeLabelValue(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestPromType(t *testing.T) {
+	tests := map[string]string{
+		"c": "counter",
+		"g": "gauge",
+		"s": "gauge",
+		"d": "summary",
+		"h": "histogram",
+		"?": "untyped",
+	}
+	for in, want := range tests {
+		if got := promType(in); got != want {
+			t.Errorf("promType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseFloats_StripsCreatedAtSuffix(t *testing.T) {
+	got := parseFloats(":1:2:3|T42")
+	want := []float64{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("parseFloats() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseFloats()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+type fakeSource struct {
+	metrics []sentry.Metric
+}
+
+func (f fakeSource) Metrics() []sentry.Metric { return f.metrics }
+
+func TestRender_Counter(t *testing.T) {
+	c := sentry.NewCounterMetric("requests", sentry.Second(), map[string]string{"status": "200"}, 0, 0, 5)
+	out := render([]sentry.Metric{c})
+
+	if !strings.Contains(out, "# TYPE requests counter") {
+		t.Errorf("render() = %q, want a counter TYPE line", out)
+	}
+	if !strings.Contains(out, `requests{status="200"} 5`) {
+		t.Errorf("render() = %q, want the sample line with status label", out)
+	}
+}
+
+func TestRender_Histogram(t *testing.T) {
+	h := sentry.NewHistogramMetric("latency", sentry.Second(), nil, 0, []float64{1, 5}, 2)
+	out := render([]sentry.Metric{h})
+
+	if !strings.Contains(out, "# TYPE latency histogram") {
+		t.Errorf("render() = %q, want a histogram TYPE line", out)
+	}
+	if !strings.Contains(out, `latency_bucket{le="1"}`) {
+		t.Errorf("render() = %q, want a le=\"1\" bucket sample", out)
+	}
+	if !strings.Contains(out, `latency_bucket{le="+Inf"}`) {
+		t.Errorf("render() = %q, want a le=\"+Inf\" bucket sample", out)
+	}
+}
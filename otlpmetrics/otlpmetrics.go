@@ -0,0 +1,332 @@
+// Package otlpmetrics converts sentry's in-process metrics into OTLP
+// metrics.v1 protobuf and ships them to an OTel collector, as an alternative
+// to the statsd-style envelope sink. It implements sentry.MetricSink, so it
+// can be registered on a client alongside (or instead of) the envelope sink;
+// wiring that registration into the client's flush loop is left to the
+// client package, which is not part of this tree.
+package otlpmetrics
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+
+	collectormetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+
+	sentry "github.com/getsentry/sentry-go"
+)
+
+// EndpointEnvVar is the environment variable consulted by EndpointFromEnv,
+// mirroring how the DSN is normally read from SENTRY_DSN.
+const EndpointEnvVar = "SENTRY_OTLP_ENDPOINT"
+
+// EndpointFromEnv returns the endpoint configured via SENTRY_OTLP_ENDPOINT,
+// or "" if unset.
+func EndpointFromEnv() string {
+	return os.Getenv(EndpointEnvVar)
+}
+
+// ucumUnits maps the unit strings produced by sentry.MetricUnit (via
+// GetUnit) to UCUM codes, as required by the OTLP unit field.
+var ucumUnits = map[string]string{
+	"nanosecond":  "ns",
+	"microsecond": "us",
+	"millisecond": "ms",
+	"second":      "s",
+	"minute":      "min",
+	"hour":        "h",
+	"day":         "d",
+	"week":        "wk",
+	"bit":         "bit",
+	"byte":        "By",
+	"kilobyte":    "kBy",
+	"kibibyte":    "kiBy",
+	"megabyte":    "MBy",
+	"mebibyte":    "MiBy",
+	"gigabyte":    "GBy",
+	"gibibyte":    "GiBy",
+	"terabyte":    "TBy",
+	"tebibyte":    "TiBy",
+	"petabyte":    "PBy",
+	"pebibyte":    "PiBy",
+	"exabyte":     "EBy",
+	"exbibyte":    "EiBy",
+	"ratio":       "1",
+	"percent":     "%",
+}
+
+func ucumUnit(unit string) string {
+	if u, ok := ucumUnits[unit]; ok {
+		return u
+	}
+	return unit
+}
+
+// Exporter ships metric snapshots to an OTel collector over gRPC.
+type Exporter struct {
+	endpoint string
+	client   collectormetricpb.MetricsServiceClient
+	conn     *grpc.ClientConn
+	timeout  time.Duration
+}
+
+// NewExporter dials endpoint (an OTel collector OTLP/gRPC address) and
+// returns an Exporter. If endpoint is "", it falls back to
+// SENTRY_OTLP_ENDPOINT.
+func NewExporter(endpoint string) (*Exporter, error) {
+	if endpoint == "" {
+		endpoint = EndpointFromEnv()
+	}
+
+	conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Exporter{
+		endpoint: endpoint,
+		client:   collectormetricpb.NewMetricsServiceClient(conn),
+		conn:     conn,
+		timeout:  10 * time.Second,
+	}, nil
+}
+
+// Close tears down the underlying gRPC connection.
+func (e *Exporter) Close() error {
+	return e.conn.Close()
+}
+
+// Flush implements sentry.MetricSink by converting snapshots into an OTLP
+// ExportMetricsServiceRequest and sending it to the configured collector.
+func (e *Exporter) Flush(snapshots []sentry.MetricSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	req := &collectormetricpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricpb.ResourceMetrics{
+			{
+				Resource: &resourcepb.Resource{},
+				ScopeMetrics: []*metricpb.ScopeMetrics{
+					{
+						Scope:   &commonpb.InstrumentationScope{Name: "sentry-go"},
+						Metrics: convertAll(snapshots),
+					},
+				},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	_, err := e.client.Export(ctx, req)
+	return err
+}
+
+func convertAll(snapshots []sentry.MetricSnapshot) []*metricpb.Metric {
+	metrics := make([]*metricpb.Metric, 0, len(snapshots))
+	for _, s := range snapshots {
+		metrics = append(metrics, convert(s)...)
+	}
+	return metrics
+}
+
+func convert(s sentry.MetricSnapshot) []*metricpb.Metric {
+	now := uint64(s.GetTimestamp()) * uint64(time.Second)
+	attrs := tagAttributes(s.GetTags())
+
+	switch s.GetType() {
+	case "c":
+		// CounterMetric.Snapshot never resets the running total (metrics.go),
+		// so the value reported here is cumulative since the counter was
+		// constructed, not a per-flush delta.
+		values := parseFloats(s.SerializeValue())
+		return []*metricpb.Metric{sumMetric(s, attrs, now, values[0], metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE)}
+
+	case "s":
+		// SetMetric.Snapshot swaps out the membership map on every call, so
+		// the unique count here is genuinely scoped to this flush window.
+		values := parseFloats(s.SerializeValue())
+		return []*metricpb.Metric{sumMetric(s, attrs, now, float64(len(values)), metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA)}
+
+	case "g":
+		return convertGauge(s, attrs, now)
+
+	case "d", "h":
+		return []*metricpb.Metric{convertHistogram(s, attrs, now)}
+
+	default:
+		return nil
+	}
+}
+
+func sumMetric(s sentry.MetricSnapshot, attrs []*commonpb.KeyValue, now uint64, value float64, temporality metricpb.AggregationTemporality) *metricpb.Metric {
+	return &metricpb.Metric{
+		Name: s.GetKey(),
+		Unit: ucumUnit(s.GetUnit()),
+		Data: &metricpb.Metric_Sum{
+			Sum: &metricpb.Sum{
+				AggregationTemporality: temporality,
+				IsMonotonic:            true,
+				DataPoints: []*metricpb.NumberDataPoint{
+					{
+						StartTimeUnixNano: uint64(s.GetCreatedAt()) * uint64(time.Second),
+						TimeUnixNano:      now,
+						Attributes:        attrs,
+						Value:             &metricpb.NumberDataPoint_AsDouble{AsDouble: value},
+					},
+				},
+			},
+		},
+	}
+}
+
+// convertGauge emits the live .last value as a Gauge, plus sibling
+// <key>_min/_max/_sum/_count series, mirroring metricsprom's rendering of the
+// same GaugeMetric.SerializeValue fields.
+func convertGauge(s sentry.MetricSnapshot, attrs []*commonpb.KeyValue, now uint64) []*metricpb.Metric {
+	values := parseFloats(s.SerializeValue())
+	last, min, max, sum, count := values[0], values[1], values[2], values[3], values[4]
+
+	gauge := func(name string, value float64) *metricpb.Metric {
+		return &metricpb.Metric{
+			Name: name,
+			Unit: ucumUnit(s.GetUnit()),
+			Data: &metricpb.Metric_Gauge{
+				Gauge: &metricpb.Gauge{
+					DataPoints: []*metricpb.NumberDataPoint{
+						{
+							StartTimeUnixNano: uint64(s.GetCreatedAt()) * uint64(time.Second),
+							TimeUnixNano:      now,
+							Attributes:        attrs,
+							Value:             &metricpb.NumberDataPoint_AsDouble{AsDouble: value},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	key := s.GetKey()
+	return []*metricpb.Metric{
+		gauge(key, last),
+		gauge(key+"_min", min),
+		gauge(key+"_max", max),
+		gauge(key+"_sum", sum),
+		gauge(key+"_count", count),
+	}
+}
+
+func convertHistogram(s sentry.MetricSnapshot, attrs []*commonpb.KeyValue, now uint64) *metricpb.Metric {
+	values := parseFloats(s.SerializeValue())
+
+	var sum, count float64
+	var bucketCounts []uint64
+	var explicitBounds []float64
+	temporality := metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+
+	if s.GetType() == "h" {
+		// HistogramMetric.Snapshot copies its counts without resetting them
+		// (metrics.go), so like a counter they are cumulative since construction.
+		temporality = metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+		// sentry.HistogramMetric: :sum:count:min:max:b0:...:bN
+		sum, count = values[0], values[1]
+		for _, c := range values[4:] {
+			bucketCounts = append(bucketCounts, uint64(c))
+		}
+		// OTLP requires len(BucketCounts) == len(ExplicitBounds)+1; recover the
+		// configured bucket boundaries via HistogramSnapshot so the +Inf-terminated
+		// bucketCounts above lines up with an explicit bound per finite edge.
+		if hist, ok := s.(sentry.HistogramSnapshot); ok {
+			explicitBounds = hist.Buckets()
+		}
+	} else {
+		// sentry.DistributionMetric: raw samples, reported as a single catch-all
+		// bucket with no explicit bounds.
+		sort.Float64s(values)
+		for _, v := range values {
+			sum += v
+			count++
+		}
+		bucketCounts = []uint64{uint64(len(values))}
+	}
+
+	return &metricpb.Metric{
+		Name: s.GetKey(),
+		Unit: ucumUnit(s.GetUnit()),
+		Data: &metricpb.Metric_Histogram{
+			Histogram: &metricpb.Histogram{
+				AggregationTemporality: temporality,
+				DataPoints: []*metricpb.HistogramDataPoint{
+					{
+						StartTimeUnixNano: uint64(s.GetCreatedAt()) * uint64(time.Second),
+						TimeUnixNano:      now,
+						Attributes:        attrs,
+						Count:             uint64(count),
+						Sum:               &sum,
+						BucketCounts:      bucketCounts,
+						ExplicitBounds:    explicitBounds,
+					},
+				},
+			},
+		},
+	}
+}
+
+// attrKeyRegex sanitizes tag keys into valid OTLP attribute keys, mirroring
+// metricsprom's promLabelRegex/sanitizeLabel handling of the same tags.
+var attrKeyRegex = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+func sanitizeAttrKey(s string) string {
+	s = attrKeyRegex.ReplaceAllString(s, "_")
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "_" + s
+	}
+	return s
+}
+
+func tagAttributes(tags map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, &commonpb.KeyValue{
+			Key:   sanitizeAttrKey(k),
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: tags[k]}},
+		})
+	}
+	return attrs
+}
+
+func parseFloats(serialized string) []float64 {
+	// Strip the "|T<created>" suffix (see abstractMetric.serializeCreatedAt)
+	// before splitting on ":", otherwise the last numeric field is swallowed
+	// along with it.
+	if i := strings.IndexByte(serialized, '|'); i >= 0 {
+		serialized = serialized[:i]
+	}
+	parts := strings.Split(strings.TrimPrefix(serialized, ":"), ":")
+	values := make([]float64, 0, len(parts))
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			continue
+		}
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,170 @@
+package otlpmetrics
+
+import (
+	"testing"
+	"time"
+
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+
+	sentry "github.com/getsentry/sentry-go"
+)
+
+func TestUcumUnit(t *testing.T) {
+	tests := map[string]string{
+		"second":      "s",
+		"millisecond": "ms",
+		"byte":        "By",
+		"percent":     "%",
+		"bogus-unit":  "bogus-unit",
+	}
+	for in, want := range tests {
+		if got := ucumUnit(in); got != want {
+			t.Errorf("ucumUnit(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseFloats_StripsCreatedAtSuffix(t *testing.T) {
+	got := parseFloats(":1:2:3|T42")
+	want := []float64{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("parseFloats() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseFloats()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTagAttributes_SortedByKey(t *testing.T) {
+	attrs := tagAttributes(map[string]string{"b": "2", "a": "1"})
+	if len(attrs) != 2 {
+		t.Fatalf("tagAttributes() = %v, want 2 entries", attrs)
+	}
+	if attrs[0].Key != "a" || attrs[1].Key != "b" {
+		t.Errorf("tagAttributes() = %v, want keys sorted a before b", attrs)
+	}
+}
+
+func TestTagAttributes_SanitizesKeys(t *testing.T) {
+	attrs := tagAttributes(map[string]string{"http.status": "200"})
+	if len(attrs) != 1 {
+		t.Fatalf("tagAttributes() = %v, want 1 entry", attrs)
+	}
+	if attrs[0].Key != "http_status" {
+		t.Errorf("tagAttributes() key = %q, want %q", attrs[0].Key, "http_status")
+	}
+}
+
+func TestConvert_Counter(t *testing.T) {
+	c := sentry.NewCounterMetric("requests", sentry.Second(), nil, 0, 0, 5)
+	metrics := convert(c.Snapshot())
+
+	if len(metrics) != 1 {
+		t.Fatalf("convert() = %d metrics, want 1", len(metrics))
+	}
+	sum, ok := metrics[0].Data.(*metricpb.Metric_Sum)
+	if !ok {
+		t.Fatalf("convert() Data = %T, want *metricpb.Metric_Sum", metrics[0].Data)
+	}
+	if got := sum.Sum.DataPoints[0].GetAsDouble(); got != 5 {
+		t.Errorf("counter value = %v, want 5", got)
+	}
+	// CounterMetric never resets on Snapshot, so the value reported is
+	// cumulative since construction, not a per-flush delta.
+	if sum.Sum.AggregationTemporality != metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+		t.Errorf("counter temporality = %v, want CUMULATIVE", sum.Sum.AggregationTemporality)
+	}
+}
+
+func TestConvert_Set_IsDeltaTemporality(t *testing.T) {
+	s := sentry.NewSetMetric[int]("unique_users", sentry.Second(), nil, 0, 0, 1)
+	metrics := convert(s.Snapshot())
+
+	sum, ok := metrics[0].Data.(*metricpb.Metric_Sum)
+	if !ok {
+		t.Fatalf("convert() Data = %T, want *metricpb.Metric_Sum", metrics[0].Data)
+	}
+	// SetMetric.Snapshot swaps out its membership map every call, so the
+	// count is scoped to this flush window.
+	if sum.Sum.AggregationTemporality != metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA {
+		t.Errorf("set temporality = %v, want DELTA", sum.Sum.AggregationTemporality)
+	}
+}
+
+func TestConvertGauge_EmitsLastPlusSiblingSeries(t *testing.T) {
+	g := sentry.NewGaugeMetric("pool_size", sentry.Second(), nil, 0, 0, 3)
+	g.Add(7.0)
+
+	metrics := convert(g.Snapshot())
+
+	want := []string{"pool_size", "pool_size_min", "pool_size_max", "pool_size_sum", "pool_size_count"}
+	if len(metrics) != len(want) {
+		t.Fatalf("convert() = %d metrics, want %d", len(metrics), len(want))
+	}
+	for i, name := range want {
+		if metrics[i].Name != name {
+			t.Errorf("metrics[%d].Name = %q, want %q", i, metrics[i].Name, name)
+		}
+		if _, ok := metrics[i].Data.(*metricpb.Metric_Gauge); !ok {
+			t.Errorf("metrics[%d].Data = %T, want *metricpb.Metric_Gauge", i, metrics[i].Data)
+		}
+	}
+
+	last := metrics[0].Data.(*metricpb.Metric_Gauge).Gauge.DataPoints[0].GetAsDouble()
+	if last != 7 {
+		t.Errorf("last gauge value = %v, want 7", last)
+	}
+}
+
+func TestConvertHistogram_PopulatesExplicitBoundsFromBuckets(t *testing.T) {
+	h := sentry.NewHistogramMetric("latency", sentry.Second(), nil, 0, []float64{1, 5, 10}, 2)
+	metrics := convert(h.Snapshot())
+
+	if len(metrics) != 1 {
+		t.Fatalf("convert() = %d metrics, want 1", len(metrics))
+	}
+	hist, ok := metrics[0].Data.(*metricpb.Metric_Histogram)
+	if !ok {
+		t.Fatalf("convert() Data = %T, want *metricpb.Metric_Histogram", metrics[0].Data)
+	}
+
+	dp := hist.Histogram.DataPoints[0]
+	if len(dp.BucketCounts) != len(dp.ExplicitBounds)+1 {
+		t.Errorf("len(BucketCounts) = %d, len(ExplicitBounds) = %d, want BucketCounts one longer",
+			len(dp.BucketCounts), len(dp.ExplicitBounds))
+	}
+	want := []float64{1, 5, 10}
+	if len(dp.ExplicitBounds) != len(want) {
+		t.Fatalf("ExplicitBounds = %v, want %v", dp.ExplicitBounds, want)
+	}
+	for i, b := range want {
+		if dp.ExplicitBounds[i] != b {
+			t.Errorf("ExplicitBounds[%d] = %v, want %v", i, dp.ExplicitBounds[i], b)
+		}
+	}
+}
+
+func TestConvert_SetsStartTimeFromCreatedAt(t *testing.T) {
+	c := sentry.NewCounterMetric("requests", sentry.Second(), nil, 100, 42, 5)
+	metrics := convert(c.Snapshot())
+
+	sum := metrics[0].Data.(*metricpb.Metric_Sum)
+	want := uint64(42) * uint64(time.Second)
+	if got := sum.Sum.DataPoints[0].StartTimeUnixNano; got != want {
+		t.Errorf("StartTimeUnixNano = %d, want %d", got, want)
+	}
+}
+
+func TestConvertHistogram_DistributionHasNoExplicitBounds(t *testing.T) {
+	d := sentry.NewDistributionMetric("sizes", sentry.Byte(), nil, 0, 0, 4)
+	metrics := convert(d.Snapshot())
+
+	hist := metrics[0].Data.(*metricpb.Metric_Histogram)
+	dp := hist.Histogram.DataPoints[0]
+	if len(dp.BucketCounts) != len(dp.ExplicitBounds)+1 {
+		t.Errorf("len(BucketCounts) = %d, len(ExplicitBounds) = %d, want BucketCounts one longer",
+			len(dp.BucketCounts), len(dp.ExplicitBounds))
+	}
+}